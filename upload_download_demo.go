@@ -3,19 +3,27 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"math"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/0gfoundation/0g-storage-client/common/blockchain"
+	"github.com/0gfoundation/0g-storage-client/common/shard"
 	"github.com/0gfoundation/0g-storage-client/core"
 	"github.com/0gfoundation/0g-storage-client/indexer"
+	"github.com/0gfoundation/0g-storage-client/node"
 	"github.com/0gfoundation/0g-storage-client/transfer"
 	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
 	web3go "github.com/openweb3/web3go"
 	"github.com/sirupsen/logrus"
@@ -32,12 +40,13 @@ type Config struct {
 		Endpoint string `json:"endpoint"`
 	} `json:"indexer"`
 	File struct {
-		InputFile         string `json:"input_file"`
-		OutputDirectory   string `json:"output_directory"`
-		FragmentSize      int64  `json:"fragment_size"`
-		NumberOfParts     int    `json:"number_of_parts"`
-		GenerateTestFile  bool   `json:"generate_test_file"`
-		TestFileSize      int64  `json:"test_file_size"`
+		InputFile        string `json:"input_file"`
+		OutputDirectory  string `json:"output_directory"`
+		FragmentSize     int64  `json:"fragment_size"`
+		NumberOfParts    int    `json:"number_of_parts"`
+		GenerateTestFile bool   `json:"generate_test_file"`
+		TestFileSize     int64  `json:"test_file_size"`
+		StreamMode       bool   `json:"stream_mode"`
 	} `json:"file"`
 	Upload struct {
 		ExpectedReplica int    `json:"expected_replica"`
@@ -45,11 +54,13 @@ type Config struct {
 		FullTrusted     bool   `json:"full_trusted"`
 		MaxRetries      int    `json:"max_retries"`
 		TimeoutMinutes  int    `json:"timeout_minutes"`
-		BatchSize       int    `json:"batch_size"`
+		Concurrency     int    `json:"concurrency"`
 	} `json:"upload"`
 	Download struct {
 		VerifyProof    bool `json:"verify_proof"`
 		TimeoutMinutes int  `json:"timeout_minutes"`
+		Concurrency    int  `json:"concurrency"`
+		MaxRetries     int  `json:"max_retries"`
 	} `json:"download"`
 }
 
@@ -122,18 +133,158 @@ func (d *Demo) GenerateTestFile(filename string, size int64) error {
 	return nil
 }
 
+// PartStatus tracks a split part's progress through the upload pipeline so a
+// crashed run can tell, on restart, which parts still need work.
+//
+// There is no "finalized" state: uploads use FinalityRequired:
+// transfer.TransactionPacked (see uploadSingleFile/uploadFragment) to avoid
+// blocking on full on-chain finalization, so a part's resumable lifecycle
+// never observes anything past "packed". Tracking finalization would require
+// polling node.ZgsClient.CheckFileFinalized (or switching FinalityRequired to
+// transfer.FileFinalized and eating the latency) after the fact; out of
+// scope for this resumable-upload pass.
+type PartStatus string
+
+const (
+	PartStatusPending PartStatus = "pending"
+	PartStatusPacked  PartStatus = "packed"
+)
+
+// PartManifestEntry records everything needed to resume or re-verify a
+// single split part without re-reading the original input file.
+type PartManifestEntry struct {
+	FilePath string     `json:"file_path"`
+	Size     int64      `json:"size"`
+	RootHash string     `json:"root_hash,omitempty"`
+	TxHash   string     `json:"tx_hash,omitempty"`
+	Status   PartStatus `json:"status"`
+}
+
+// UploadManifest is a JSON-backed record of every part's upload progress,
+// written next to the parts directory (upload_manifest.json) so a re-run of
+// the demo resumes exactly where a previous run stopped instead of
+// re-uploading everything.
+type UploadManifest struct {
+	path  string
+	mu    sync.Mutex
+	Parts []PartManifestEntry `json:"parts"`
+}
+
+// uploadManifestPath returns where the manifest lives for a given parts
+// directory: one level up, alongside it rather than inside it.
+func uploadManifestPath(partsDir string) string {
+	return filepath.Join(filepath.Dir(partsDir), "upload_manifest.json")
+}
+
+// LoadOrCreateUploadManifest loads an existing manifest from path, or
+// returns an empty one ready to be populated if none exists yet.
+func LoadOrCreateUploadManifest(path string) (*UploadManifest, error) {
+	m := &UploadManifest{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload manifest: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &m.Parts); err != nil {
+		return nil, fmt.Errorf("failed to parse upload manifest: %w", err)
+	}
+
+	return m, nil
+}
+
+// Save flushes the manifest to disk, overwriting any previous contents.
+func (m *UploadManifest) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.saveLocked()
+}
+
+func (m *UploadManifest) saveLocked() error {
+	data, err := json.MarshalIndent(m.Parts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal upload manifest: %w", err)
+	}
+	if err := os.WriteFile(m.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write upload manifest: %w", err)
+	}
+	return nil
+}
+
+// ensurePending registers filePath as a pending entry if it isn't already
+// tracked, leaving any existing entry (and its status) untouched.
+func (m *UploadManifest) ensurePending(filePath string, size int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.Parts {
+		if m.Parts[i].FilePath == filePath {
+			return
+		}
+	}
+
+	m.Parts = append(m.Parts, PartManifestEntry{
+		FilePath: filePath,
+		Size:     size,
+		Status:   PartStatusPending,
+	})
+}
+
+// resultFor returns the hashes recorded for filePath if it is already in a
+// terminal state, so the caller can skip re-uploading it.
+func (m *UploadManifest) resultFor(filePath string) (ethcommon.Hash, ethcommon.Hash, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, p := range m.Parts {
+		if p.FilePath == filePath && p.Status == PartStatusPacked {
+			return ethcommon.HexToHash(p.TxHash), ethcommon.HexToHash(p.RootHash), true
+		}
+	}
+	return ethcommon.Hash{}, ethcommon.Hash{}, false
+}
+
+// updateStatus transactionally updates filePath's entry and flushes the
+// manifest to disk immediately so progress survives a crash.
+func (m *UploadManifest) updateStatus(filePath string, status PartStatus, rootHash, txHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.Parts {
+		if m.Parts[i].FilePath == filePath {
+			m.Parts[i].Status = status
+			if rootHash != "" {
+				m.Parts[i].RootHash = rootHash
+			}
+			if txHash != "" {
+				m.Parts[i].TxHash = txHash
+			}
+			return m.saveLocked()
+		}
+	}
+	return fmt.Errorf("no manifest entry for part %s", filePath)
+}
+
 // SplitFile splits a large file into multiple parts
-func (d *Demo) SplitFile(inputFile string, outputDir string) ([]string, error) {
+func (d *Demo) SplitFile(inputFile string, outputDir string) ([]string, *UploadManifest, error) {
 	d.logger.Infof("Splitting file: %s into %d parts", inputFile, d.config.File.NumberOfParts)
 
 	input, err := os.Open(inputFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open input file: %w", err)
+		return nil, nil, fmt.Errorf("failed to open input file: %w", err)
 	}
 	defer input.Close()
 
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create output directory: %w", err)
+		return nil, nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	manifest, err := LoadOrCreateUploadManifest(uploadManifestPath(outputDir))
+	if err != nil {
+		return nil, nil, err
 	}
 
 	var partFiles []string
@@ -144,7 +295,7 @@ func (d *Demo) SplitFile(inputFile string, outputDir string) ([]string, error) {
 
 		n, err := input.Read(buffer)
 		if err != nil && err != io.EOF {
-			return nil, fmt.Errorf("failed to read part %d: %w", i, err)
+			return nil, nil, fmt.Errorf("failed to read part %d: %w", i, err)
 		}
 
 		if n == 0 {
@@ -153,16 +304,17 @@ func (d *Demo) SplitFile(inputFile string, outputDir string) ([]string, error) {
 
 		partFile, err := os.Create(partFilename)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create part file %d: %w", i, err)
+			return nil, nil, fmt.Errorf("failed to create part file %d: %w", i, err)
 		}
 
 		if _, err := partFile.Write(buffer[:n]); err != nil {
 			partFile.Close()
-			return nil, fmt.Errorf("failed to write part %d: %w", i, err)
+			return nil, nil, fmt.Errorf("failed to write part %d: %w", i, err)
 		}
 
 		partFile.Close()
 		partFiles = append(partFiles, partFilename)
+		manifest.ensurePending(partFilename, int64(n))
 
 		d.logger.Infof("Created part %d: %s (%.2f MB)", i+1, partFilename, float64(n)/(1024*1024))
 
@@ -171,87 +323,174 @@ func (d *Demo) SplitFile(inputFile string, outputDir string) ([]string, error) {
 		}
 	}
 
-	return partFiles, nil
+	if err := manifest.Save(); err != nil {
+		return nil, nil, err
+	}
+
+	return partFiles, manifest, nil
+}
+
+// uploadResult holds the outcome of a single part upload, keyed by its
+// position in the original partFiles slice so the pool can reassemble
+// results in order once every worker has finished.
+type uploadResult struct {
+	txHash   ethcommon.Hash
+	rootHash ethcommon.Hash
 }
 
-// UploadParts uploads file parts in batches with enhanced error handling
-func (d *Demo) UploadParts(partFiles []string) ([]ethcommon.Hash, []ethcommon.Hash, error) {
-	d.logger.Infof("Starting upload of %d parts with batch size %d", len(partFiles), d.config.Upload.BatchSize)
+// UploadParts uploads file parts through a bounded worker pool (size
+// Upload.Concurrency), fanning out N in-flight uploads at a time instead of
+// walking the batch serially. Parts whose manifest entry is already in a
+// terminal state are skipped, so a re-run after a crash resumes instead of
+// re-uploading everything. The first fatal error cancels every in-flight
+// upload via the shared context; results are returned in the original part
+// order regardless of completion order.
+func (d *Demo) UploadParts(partFiles []string, manifest *UploadManifest) ([]ethcommon.Hash, []ethcommon.Hash, error) {
+	concurrency := d.config.Upload.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	d.logger.Infof("Starting upload of %d parts with concurrency %d", len(partFiles), concurrency)
+
+	totalSize, err := totalFileSize(partFiles)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(d.config.Upload.TimeoutMinutes)*time.Minute)
 	defer cancel()
-
-	var txHashes []ethcommon.Hash
-	var rootHashes []ethcommon.Hash
-
-	// Upload in batches with enhanced error handling
-	batchSize := d.config.Upload.BatchSize
-	for start := 0; start < len(partFiles); start += batchSize {
-		end := start + batchSize
-		if end > len(partFiles) {
-			end = len(partFiles)
-		}
-
-		batch := partFiles[start:end]
-		d.logger.Infof("Processing batch %d-%d of %d", start+1, end, len(partFiles))
-
-		for _, partFile := range batch {
-			// Retry individual file upload with exponential backoff
-			var lastErr error
-			for retry := 0; retry < d.config.Upload.MaxRetries; retry++ {
-				if retry > 0 {
-					d.logger.Infof("Retrying upload of %s (attempt %d/%d)", partFile, retry+1, d.config.Upload.MaxRetries)
-					// Exponential backoff: 2^retry seconds
-					backoff := time.Duration(math.Pow(2, float64(retry-1))) * time.Second
-					if backoff > 30*time.Second {
-						backoff = 30 * time.Second // Cap at 30 seconds
+	ctx, cancelOnError := context.WithCancel(ctx)
+	defer cancelOnError()
+
+	results := make([]uploadResult, len(partFiles))
+	jobs := make(chan int)
+	errCh := make(chan error, 1)
+	var uploadedBytes int64
+	var wg sync.WaitGroup
+
+	progressDone := make(chan struct{})
+	go d.logUploadProgress(&uploadedBytes, totalSize, progressDone)
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				partFile := partFiles[idx]
+				txHash, rootHash, size, err := d.uploadSingleFileWithRetry(ctx, partFile, manifest)
+				if err != nil {
+					select {
+					case errCh <- fmt.Errorf("upload failed for %s after %d retries: %w", partFile, d.config.Upload.MaxRetries, err):
+						cancelOnError()
+					default:
 					}
-					time.Sleep(backoff)
+					return
 				}
-
-				if err := d.uploadSingleFile(ctx, partFile, &txHashes, &rootHashes); err != nil {
-					lastErr = err
-					d.logger.Warnf("Upload attempt %d failed for %s: %v", retry+1, partFile, err)
-					continue
-				}
-				// Success, break out of retry loop
-				lastErr = nil
-				break
+				results[idx] = uploadResult{txHash: txHash, rootHash: rootHash}
+				atomic.AddInt64(&uploadedBytes, size)
 			}
+		}()
+	}
 
-			if lastErr != nil {
-				return nil, nil, fmt.Errorf("upload failed for %s after %d retries: %w", partFile, d.config.Upload.MaxRetries, lastErr)
+feed:
+	for idx, partFile := range partFiles {
+		if txHash, rootHash, done := manifest.resultFor(partFile); done {
+			d.logger.Infof("Skipping already-uploaded part %s (resumed from manifest)", partFile)
+			results[idx] = uploadResult{txHash: txHash, rootHash: rootHash}
+			if info, statErr := os.Stat(partFile); statErr == nil {
+				atomic.AddInt64(&uploadedBytes, info.Size())
 			}
+			continue
 		}
 
-		// Wait longer between batches to avoid overwhelming the network
-		if end < len(partFiles) {
-			d.logger.Info("Waiting before next batch...")
-			time.Sleep(5 * time.Second)
+		select {
+		case jobs <- idx:
+		case <-ctx.Done():
+			break feed
 		}
 	}
+	close(jobs)
 
+	wg.Wait()
+	close(progressDone)
+
+	select {
+	case err := <-errCh:
+		return nil, nil, err
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, fmt.Errorf("upload cancelled: %w", err)
+	}
+
+	txHashes := make([]ethcommon.Hash, len(partFiles))
+	rootHashes := make([]ethcommon.Hash, len(partFiles))
+	for i, r := range results {
+		txHashes[i] = r.txHash
+		rootHashes[i] = r.rootHash
+	}
+
+	d.logger.Info("✓ All parts uploaded successfully via worker pool")
 	return txHashes, rootHashes, nil
 }
 
-// uploadSingleFile uploads a single file part using indexer node selection
-func (d *Demo) uploadSingleFile(ctx context.Context, partFile string, txHashes *[]ethcommon.Hash, rootHashes *[]ethcommon.Hash) error {
-	partIndex := len(*txHashes)
-	d.logger.Infof("Uploading part %d: %s", partIndex+1, partFile)
+// uploadSingleFileWithRetry retries uploadSingleFile with the existing
+// exponential backoff and returns the uploaded part's size alongside its
+// hashes so the caller can track pool-wide progress.
+func (d *Demo) uploadSingleFileWithRetry(ctx context.Context, partFile string, manifest *UploadManifest) (ethcommon.Hash, ethcommon.Hash, int64, error) {
+	var lastErr error
+	for retry := 0; retry < d.config.Upload.MaxRetries; retry++ {
+		if retry > 0 {
+			d.logger.Infof("Retrying upload of %s (attempt %d/%d)", partFile, retry+1, d.config.Upload.MaxRetries)
+			// Exponential backoff: 2^(retry-1) seconds
+			backoff := time.Duration(math.Pow(2, float64(retry-1))) * time.Second
+			if backoff > 30*time.Second {
+				backoff = 30 * time.Second // Cap at 30 seconds
+			}
+			time.Sleep(backoff)
+		}
+
+		txHash, rootHash, size, err := d.uploadSingleFile(ctx, partFile, manifest)
+		if err != nil {
+			lastErr = err
+			d.logger.Warnf("Upload attempt %d failed for %s: %v", retry+1, partFile, err)
+			if ctx.Err() != nil {
+				return ethcommon.Hash{}, ethcommon.Hash{}, 0, ctx.Err()
+			}
+			continue
+		}
+		return txHash, rootHash, size, nil
+	}
+
+	return ethcommon.Hash{}, ethcommon.Hash{}, 0, lastErr
+}
+
+// uploadSingleFile uploads a single file part using indexer node selection,
+// then transactionally marks the part packed in the manifest so a crash
+// between here and finalization still resumes correctly. manifest may be nil
+// for ad-hoc uploads (e.g. publishing a manifest file itself) that aren't
+// tracked as resumable parts.
+func (d *Demo) uploadSingleFile(ctx context.Context, partFile string, manifest *UploadManifest) (ethcommon.Hash, ethcommon.Hash, int64, error) {
+	d.logger.Infof("Uploading part: %s", partFile)
 
 	// Get root hash for verification first
 	rootHash, err := core.MerkleRoot(partFile)
 	if err != nil {
-		return fmt.Errorf("failed to get merkle root: %w", err)
+		return ethcommon.Hash{}, ethcommon.Hash{}, 0, fmt.Errorf("failed to get merkle root: %w", err)
 	}
 
 	// Open the file part
 	data, err := core.Open(partFile)
 	if err != nil {
-		return fmt.Errorf("failed to open part file: %w", err)
+		return ethcommon.Hash{}, ethcommon.Hash{}, 0, fmt.Errorf("failed to open part file: %w", err)
 	}
 	defer data.Close()
 
+	info, err := os.Stat(partFile)
+	if err != nil {
+		return ethcommon.Hash{}, ethcommon.Hash{}, 0, fmt.Errorf("failed to stat part file: %w", err)
+	}
+
 	// Create blockchain client with proper configuration
 	w3Client := blockchain.MustNewWeb3(d.config.Blockchain.RPCEndpoint, d.config.Blockchain.PrivateKey, providers.Option{})
 	defer w3Client.Close()
@@ -272,44 +511,486 @@ func (d *Demo) uploadSingleFile(ctx context.Context, partFile string, txHashes *
 	// Use indexer client for automatic node selection and upload
 	txHash, err := d.indexerClient.Upload(uploadCtx, w3Client, data, uploadOpt)
 	if err != nil {
-		return fmt.Errorf("upload via indexer failed: %w", err)
+		return ethcommon.Hash{}, ethcommon.Hash{}, 0, fmt.Errorf("upload via indexer failed: %w", err)
 	}
 
-	*txHashes = append(*txHashes, txHash)
-	*rootHashes = append(*rootHashes, rootHash)
+	if manifest != nil {
+		if err := manifest.updateStatus(partFile, PartStatusPacked, rootHash.Hex(), txHash.Hex()); err != nil {
+			return ethcommon.Hash{}, ethcommon.Hash{}, 0, fmt.Errorf("failed to update upload manifest: %w", err)
+		}
+	}
 
-	d.logger.Infof("✓ Part %d uploaded successfully via indexer - TxHash: %s, RootHash: %s",
-		partIndex+1, txHash.Hex(), rootHash.Hex())
+	d.logger.Infof("✓ Part uploaded successfully via indexer - TxHash: %s, RootHash: %s", txHash.Hex(), rootHash.Hex())
 
-	return nil
+	return txHash, rootHash, info.Size(), nil
 }
 
-// DownloadParts downloads all file parts
+// logUploadProgress periodically logs cumulative bytes uploaded across the
+// whole worker pool until done is closed, giving operators a throughput view
+// instead of one log line per part.
+func (d *Demo) logUploadProgress(uploadedBytes *int64, totalBytes int64, done <-chan struct{}) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			uploaded := atomic.LoadInt64(uploadedBytes)
+			d.logger.Infof("Progress: %.2f/%.2f MB uploaded", float64(uploaded)/(1024*1024), float64(totalBytes)/(1024*1024))
+		case <-done:
+			return
+		}
+	}
+}
+
+// totalFileSize sums the on-disk size of every part file, used to size the
+// pool-wide progress logger.
+func totalFileSize(partFiles []string) (int64, error) {
+	var total int64
+	for _, partFile := range partFiles {
+		info, err := os.Stat(partFile)
+		if err != nil {
+			return 0, fmt.Errorf("failed to stat part file %s: %w", partFile, err)
+		}
+		total += info.Size()
+	}
+	return total, nil
+}
+
+// StreamUpload replaces the SplitFile → UploadParts two-phase disk pipeline
+// with a single pass: the input is read in FragmentSize windows, each window
+// is wrapped in a core.DataInMemory buffer and handed directly to
+// indexerClient.Upload, so part_XX.bin files are never created. Fragments
+// are read ahead into a job channel buffered to Upload.Concurrency and
+// uploaded by a bounded pool of Upload.Concurrency workers, like UploadParts.
+// At most about 2 × Upload.Concurrency + 2 fragment-sized buffers are held in
+// memory at once (one per worker, a full channel, the read buffer and one
+// fragment waiting to be queued), rather than the full file.
+func (d *Demo) StreamUpload(inputFile string) ([]ethcommon.Hash, []ethcommon.Hash, error) {
+	concurrency := d.config.Upload.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	d.logger.Infof("Streaming upload of %s with fragment size %d bytes and concurrency %d",
+		inputFile, d.config.File.FragmentSize, concurrency)
+
+	input, err := os.Open(inputFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer input.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(d.config.Upload.TimeoutMinutes)*time.Minute)
+	defer cancel()
+	ctx, cancelOnError := context.WithCancel(ctx)
+	defer cancelOnError()
+
+	type fragmentJob struct {
+		index int
+		data  []byte
+	}
+
+	jobs := make(chan fragmentJob, concurrency)
+	errCh := make(chan error, 1)
+	var mu sync.Mutex
+	var results []uploadResult
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				txHash, rootHash, err := d.uploadFragmentWithRetry(ctx, job.data, job.index)
+				if err != nil {
+					select {
+					case errCh <- fmt.Errorf("upload failed for fragment %d: %w", job.index, err):
+						cancelOnError()
+					default:
+					}
+					return
+				}
+
+				mu.Lock()
+				for len(results) <= job.index {
+					results = append(results, uploadResult{})
+				}
+				results[job.index] = uploadResult{txHash: txHash, rootHash: rootHash}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	readErr := func() error {
+		defer close(jobs)
+		buffer := make([]byte, d.config.File.FragmentSize)
+		for index := 0; ; index++ {
+			n, err := input.Read(buffer)
+			if err != nil && err != io.EOF {
+				return fmt.Errorf("failed to read fragment %d: %w", index, err)
+			}
+			if n == 0 {
+				return nil
+			}
+
+			fragment := make([]byte, n)
+			copy(fragment, buffer[:n])
+
+			select {
+			case jobs <- fragmentJob{index: index, data: fragment}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			if err == io.EOF {
+				return nil
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	if readErr != nil {
+		return nil, nil, readErr
+	}
+	select {
+	case err := <-errCh:
+		return nil, nil, err
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, fmt.Errorf("stream upload cancelled: %w", err)
+	}
+
+	txHashes := make([]ethcommon.Hash, len(results))
+	rootHashes := make([]ethcommon.Hash, len(results))
+	for i, r := range results {
+		txHashes[i] = r.txHash
+		rootHashes[i] = r.rootHash
+	}
+
+	d.logger.Infof("✓ Streamed and uploaded %d fragments without materializing part files", len(results))
+	return txHashes, rootHashes, nil
+}
+
+// uploadFragmentWithRetry retries uploadFragment with the same exponential
+// backoff as the disk-backed upload path.
+func (d *Demo) uploadFragmentWithRetry(ctx context.Context, fragment []byte, index int) (ethcommon.Hash, ethcommon.Hash, error) {
+	var lastErr error
+	for retry := 0; retry < d.config.Upload.MaxRetries; retry++ {
+		if retry > 0 {
+			d.logger.Infof("Retrying upload of fragment %d (attempt %d/%d)", index, retry+1, d.config.Upload.MaxRetries)
+			backoff := time.Duration(math.Pow(2, float64(retry-1))) * time.Second
+			if backoff > 30*time.Second {
+				backoff = 30 * time.Second
+			}
+			time.Sleep(backoff)
+		}
+
+		txHash, rootHash, err := d.uploadFragment(ctx, fragment, index)
+		if err != nil {
+			lastErr = err
+			d.logger.Warnf("Upload attempt %d failed for fragment %d: %v", retry+1, index, err)
+			if ctx.Err() != nil {
+				return ethcommon.Hash{}, ethcommon.Hash{}, ctx.Err()
+			}
+			continue
+		}
+		return txHash, rootHash, nil
+	}
+
+	return ethcommon.Hash{}, ethcommon.Hash{}, lastErr
+}
+
+// uploadFragment wraps fragment in a core.DataInMemory adapter and uploads
+// it directly through the indexer, without ever writing it to disk.
+func (d *Demo) uploadFragment(ctx context.Context, fragment []byte, index int) (ethcommon.Hash, ethcommon.Hash, error) {
+	data := core.NewDataInMemory(fragment)
+	defer data.Close()
+
+	tree, err := core.MerkleTree(data)
+	if err != nil {
+		return ethcommon.Hash{}, ethcommon.Hash{}, fmt.Errorf("failed to compute fragment merkle root: %w", err)
+	}
+	rootHash := tree.Root()
+
+	w3Client := blockchain.MustNewWeb3(d.config.Blockchain.RPCEndpoint, d.config.Blockchain.PrivateKey, providers.Option{})
+	defer w3Client.Close()
+
+	uploadCtx, cancel := context.WithTimeout(ctx, time.Duration(d.config.Upload.TimeoutMinutes)*time.Minute)
+	defer cancel()
+
+	uploadOpt := transfer.UploadOption{
+		FinalityRequired: transfer.TransactionPacked,
+		ExpectedReplica:  uint(d.config.Upload.ExpectedReplica),
+		Method:           d.config.Upload.Method,
+		FullTrusted:      d.config.Upload.FullTrusted,
+		NRetries:         d.config.Upload.MaxRetries,
+	}
+
+	txHash, err := d.indexerClient.Upload(uploadCtx, w3Client, data, uploadOpt)
+	if err != nil {
+		return ethcommon.Hash{}, ethcommon.Hash{}, fmt.Errorf("upload via indexer failed: %w", err)
+	}
+
+	d.logger.Infof("✓ Fragment %d uploaded successfully via indexer - TxHash: %s, RootHash: %s", index, txHash.Hex(), rootHash.Hex())
+	return txHash, rootHash, nil
+}
+
+// segmentRange is one independently downloaded, verified and retried
+// segment within a part. The segment is the storage layer's own
+// fixed-size download/proof unit (core.DefaultSegmentSize bytes padded to
+// core.DefaultSegmentMaxChunks chunks), so it's the finest granularity a
+// proof-verified sub-range download actually supports; offset/length
+// describe where the (possibly padding-trimmed) segment lands in the
+// output file.
+type segmentRange struct {
+	index  uint64
+	offset int64
+	length int64
+}
+
+// segmentRangesForFile lays out the segment-aligned ranges covering info's
+// file, mirroring how the storage nodes compute segment indices from a
+// file's start entry index and size.
+func segmentRangesForFile(info *node.FileInfo) []segmentRange {
+	startSegmentIndex := info.Tx.StartEntryIndex / core.DefaultSegmentMaxChunks
+	numChunks := core.NumSplits(int64(info.Tx.Size), core.DefaultChunkSize)
+	endSegmentIndex := (info.Tx.StartEntryIndex + numChunks - 1) / core.DefaultSegmentMaxChunks
+
+	ranges := make([]segmentRange, 0, endSegmentIndex-startSegmentIndex+1)
+	for segIndex := startSegmentIndex; segIndex <= endSegmentIndex; segIndex++ {
+		length := int64(core.DefaultSegmentSize)
+		if segIndex == endSegmentIndex {
+			if last := int64(info.Tx.Size) % core.DefaultSegmentSize; last > 0 {
+				length = last
+			}
+		}
+		ranges = append(ranges, segmentRange{
+			index:  segIndex,
+			offset: int64(segIndex-startSegmentIndex) * core.DefaultSegmentSize,
+			length: length,
+		})
+	}
+	return ranges
+}
+
+// DownloadParts downloads all file parts through a bounded pool of
+// concurrent downloaders (Download.Concurrency). Within each part, the file
+// is further split into fixed-size segments pulled in parallel and stitched
+// into a pre-allocated output file via WriteAt, so a single failed segment
+// only costs a segment retry rather than the whole part.
 func (d *Demo) DownloadParts(rootHashes []ethcommon.Hash, outputDir string) error {
-	d.logger.Infof("Starting download of %d parts", len(rootHashes))
+	concurrency := d.config.Download.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	d.logger.Infof("Starting download of %d parts with concurrency %d", len(rootHashes), concurrency)
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(d.config.Download.TimeoutMinutes)*time.Minute)
 	defer cancel()
+	ctx, cancelOnError := context.WithCancel(ctx)
+	defer cancelOnError()
 
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create download directory: %w", err)
 	}
 
-	for i, rootHash := range rootHashes {
-		outputFile := filepath.Join(outputDir, fmt.Sprintf("downloaded_part_%02d.bin", i))
+	jobs := make(chan int)
+	errCh := make(chan error, 1)
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				rootHash := rootHashes[i]
+				outputFile := filepath.Join(outputDir, fmt.Sprintf("downloaded_part_%02d.bin", i))
+				d.logger.Infof("Downloading part %d/%d: %s", i+1, len(rootHashes), rootHash.Hex())
+
+				if err := d.downloadPart(ctx, rootHash, outputFile); err != nil {
+					select {
+					case errCh <- fmt.Errorf("download failed for part %d: %w", i+1, err):
+						cancelOnError()
+					default:
+					}
+					return
+				}
+				d.logger.Infof("✓ Part %d downloaded successfully to %s", i+1, outputFile)
+			}
+		}()
+	}
+
+feed:
+	for i := range rootHashes {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
 
-		d.logger.Infof("Downloading part %d/%d: %s", i+1, len(rootHashes), rootHash.Hex())
+	select {
+	case err := <-errCh:
+		return err
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("download cancelled: %w", err)
+	}
+
+	return nil
+}
 
-		// Download using indexer client
-		err := d.indexerClient.Download(ctx, rootHash.Hex(), outputFile, d.config.Download.VerifyProof)
+// downloadPart resolves the storage nodes holding rootHash the same way the
+// indexer client's own downloader does, pre-allocates the output file to
+// the part's full size, then downloads every segment concurrently, each
+// writing directly into its own slot via WriteAt.
+func (d *Demo) downloadPart(ctx context.Context, rootHash ethcommon.Hash, outputFile string) error {
+	clients, err := d.downloadNodesFor(ctx, rootHash)
+	if err != nil {
+		return fmt.Errorf("failed to resolve storage nodes: %w", err)
+	}
+
+	info, err := clients[0].GetFileInfo(ctx, rootHash, true)
+	if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+	if info == nil {
+		return fmt.Errorf("file %s not found on any resolved node", rootHash.Hex())
+	}
+
+	out, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer out.Close()
+
+	if err := out.Truncate(int64(info.Tx.Size)); err != nil {
+		return fmt.Errorf("failed to pre-allocate output file: %w", err)
+	}
+
+	ranges := segmentRangesForFile(info)
+
+	concurrency := d.config.Download.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, 1)
+	sem := make(chan struct{}, concurrency)
+
+	for i, r := range ranges {
+		i, r := i, r
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := d.downloadSegmentWithRetry(ctx, clients, i, rootHash, info, r, out); err != nil {
+				select {
+				case errCh <- err:
+				default:
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	select {
+	case err := <-errCh:
+		return err
+	default:
+		return nil
+	}
+}
+
+// downloadNodesFor resolves the set of storage nodes holding rootHash,
+// mirroring indexer.Client's own NewDownloaderFromIndexerNodes: look up
+// where the file is shard-replicated, then connect a zgs client to each
+// selected node.
+func (d *Demo) downloadNodesFor(ctx context.Context, rootHash ethcommon.Hash) ([]*node.ZgsClient, error) {
+	locations, err := d.indexerClient.GetFileLocations(ctx, rootHash.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file locations: %w", err)
+	}
+
+	selected, covered := shard.Select(locations, 1, "random")
+	if !covered {
+		return nil, errors.New("file not found or shards incomplete, try again later")
+	}
+
+	clients := make([]*node.ZgsClient, 0, len(selected))
+	for _, location := range selected {
+		client, err := node.NewZgsClient(location.URL, &location.Config, providers.Option{})
 		if err != nil {
-			return fmt.Errorf("download failed for part %d: %w", i+1, err)
+			d.logger.Warnf("Failed to connect to storage node %s, dropped: %v", location.URL, err)
+			continue
 		}
+		clients = append(clients, client)
+	}
+	if len(clients) == 0 {
+		return nil, errors.New("no storage node holding the file could be reached")
+	}
 
-		d.logger.Infof("✓ Part %d downloaded successfully to %s", i+1, outputFile)
+	return clients, nil
+}
+
+// downloadSegmentWithRetry downloads and verifies one segment against the
+// merkle proof the storage node returns alongside it. Each attempt rotates
+// through every resolved node (starting from a different node per segment
+// so load spreads across the set) before sleeping and retrying the whole
+// rotation with the existing exponential backoff.
+func (d *Demo) downloadSegmentWithRetry(ctx context.Context, clients []*node.ZgsClient, start int, rootHash ethcommon.Hash, info *node.FileInfo, r segmentRange, out *os.File) error {
+	maxRetries := d.config.Download.MaxRetries
+	if maxRetries < 1 {
+		maxRetries = 1
 	}
 
-	return nil
+	var lastErr error
+	for retry := 0; retry < maxRetries; retry++ {
+		if retry > 0 {
+			backoff := time.Duration(math.Pow(2, float64(retry-1))) * time.Second
+			if backoff > 30*time.Second {
+				backoff = 30 * time.Second
+			}
+			time.Sleep(backoff)
+		}
+
+		for i := 0; i < len(clients); i++ {
+			nodeIndex := (start + i) % len(clients)
+
+			segment, err := clients[nodeIndex].DownloadSegmentWithProof(ctx, rootHash, r.index)
+			if err != nil {
+				lastErr = fmt.Errorf("transport error from node %d: %w", nodeIndex, err)
+				continue
+			}
+			if segment == nil {
+				lastErr = fmt.Errorf("segment %d not found on node %d", r.index, nodeIndex)
+				continue
+			}
+
+			segmentRootHash, numSegmentsFlowPadded := core.PaddedSegmentRoot(r.index, segment.Data, int64(info.Tx.Size))
+			if err := segment.Proof.ValidateHash(rootHash, segmentRootHash, r.index, numSegmentsFlowPadded); err != nil {
+				lastErr = fmt.Errorf("segment %d failed verification from node %d: %w", r.index, nodeIndex, err)
+				d.logger.Warnf("Segment %d failed verification from node %d, retrying on a different node", r.index, nodeIndex)
+				continue
+			}
+
+			if _, err := out.WriteAt(segment.Data[:r.length], r.offset); err != nil {
+				return fmt.Errorf("failed to write segment %d at offset %d: %w", r.index, r.offset, err)
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("segment %d failed after %d retries across %d nodes: %w", r.index, maxRetries, len(clients), lastErr)
 }
 
 // VerifyParts verifies the integrity of downloaded parts
@@ -344,9 +1025,11 @@ func (d *Demo) VerifyParts(originalParts []string, downloadedDir string) error {
 	return nil
 }
 
-// CombineParts combines all downloaded parts into a single file
-func (d *Demo) CombineParts(downloadedDir string, outputFile string) error {
-	d.logger.Infof("Combining %d parts into: %s", d.config.File.NumberOfParts, outputFile)
+// CombineParts combines all downloaded parts into a single file. numParts is
+// the actual number of downloaded parts rather than config.File.NumberOfParts,
+// since StreamUpload's fragment count need not match it.
+func (d *Demo) CombineParts(downloadedDir string, outputFile string, numParts int) error {
+	d.logger.Infof("Combining %d parts into: %s", numParts, outputFile)
 
 	output, err := os.Create(outputFile)
 	if err != nil {
@@ -354,7 +1037,7 @@ func (d *Demo) CombineParts(downloadedDir string, outputFile string) error {
 	}
 	defer output.Close()
 
-	for i := 0; i < d.config.File.NumberOfParts; i++ {
+	for i := 0; i < numParts; i++ {
 		partFile := filepath.Join(downloadedDir, fmt.Sprintf("downloaded_part_%02d.bin", i))
 
 		input, err := os.Open(partFile)
@@ -376,6 +1059,158 @@ func (d *Demo) CombineParts(downloadedDir string, outputFile string) error {
 	return nil
 }
 
+// FileMeta describes the original file a FileManifest was published for.
+type FileMeta struct {
+	Filename     string `json:"filename"`
+	TotalSize    int64  `json:"total_size"`
+	FragmentSize int64  `json:"fragment_size"`
+}
+
+// FileManifest is the small, content-addressed record a caller needs to keep
+// in order to rediscover a previously uploaded file: the ordered list of
+// part root hashes, plus an optional signature proving who published it.
+// Its own root hash, returned by PublishManifest, is the single "CID" a user
+// has to remember instead of shepherding the RootHashes slice out-of-band.
+type FileManifest struct {
+	FileMeta
+	RootHashes []ethcommon.Hash `json:"root_hashes"`
+	Signature  []byte           `json:"signature,omitempty"`
+}
+
+// signingHash hashes every manifest field except the signature itself, so
+// it can be both signed by the publisher and re-derived by a verifier.
+func (m *FileManifest) signingHash() (ethcommon.Hash, error) {
+	unsigned := struct {
+		FileMeta
+		RootHashes []ethcommon.Hash `json:"root_hashes"`
+	}{FileMeta: m.FileMeta, RootHashes: m.RootHashes}
+
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return ethcommon.Hash{}, fmt.Errorf("failed to marshal manifest for signing: %w", err)
+	}
+	return crypto.Keccak256Hash(data), nil
+}
+
+// PublishManifest serializes rootHashes and meta into a FileManifest,
+// optionally signs it with Blockchain.PrivateKey, and uploads it through the
+// same indexer path as any other part. The returned root hash is the single
+// handle a caller needs to later call DownloadByManifest.
+func (d *Demo) PublishManifest(rootHashes []ethcommon.Hash, meta FileMeta) (ethcommon.Hash, error) {
+	manifest := FileManifest{FileMeta: meta, RootHashes: rootHashes}
+
+	if d.config.Blockchain.PrivateKey != "" {
+		privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(d.config.Blockchain.PrivateKey, "0x"))
+		if err != nil {
+			return ethcommon.Hash{}, fmt.Errorf("failed to parse private key: %w", err)
+		}
+
+		hash, err := manifest.signingHash()
+		if err != nil {
+			return ethcommon.Hash{}, err
+		}
+
+		signature, err := crypto.Sign(hash.Bytes(), privateKey)
+		if err != nil {
+			return ethcommon.Hash{}, fmt.Errorf("failed to sign manifest: %w", err)
+		}
+		manifest.Signature = signature
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return ethcommon.Hash{}, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	manifestFile, err := os.CreateTemp("", "manifest-*.json")
+	if err != nil {
+		return ethcommon.Hash{}, fmt.Errorf("failed to create temp manifest file: %w", err)
+	}
+	defer os.Remove(manifestFile.Name())
+
+	if _, err := manifestFile.Write(data); err != nil {
+		manifestFile.Close()
+		return ethcommon.Hash{}, fmt.Errorf("failed to write temp manifest file: %w", err)
+	}
+	manifestFile.Close()
+
+	_, rootHash, _, err := d.uploadSingleFile(context.Background(), manifestFile.Name(), nil)
+	if err != nil {
+		return ethcommon.Hash{}, fmt.Errorf("failed to upload manifest: %w", err)
+	}
+
+	d.logger.Infof("✓ Manifest published - CID: %s (%d parts)", rootHash.Hex(), len(rootHashes))
+	return rootHash, nil
+}
+
+// DownloadByManifest downloads the FileManifest addressed by manifestRoot,
+// verifies its signature if present, then drives DownloadParts and
+// CombineParts from its embedded root hash list — the symmetric counterpart
+// to PublishManifest, so a caller only has to remember one hash to get the
+// whole file back.
+func (d *Demo) DownloadByManifest(manifestRoot ethcommon.Hash, outputFile string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(d.config.Download.TimeoutMinutes)*time.Minute)
+	defer cancel()
+
+	manifestFile, err := os.CreateTemp("", "manifest-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create temp manifest file: %w", err)
+	}
+	defer os.Remove(manifestFile.Name())
+	manifestFile.Close()
+
+	if err := d.indexerClient.Download(ctx, manifestRoot.Hex(), manifestFile.Name(), d.config.Download.VerifyProof); err != nil {
+		return fmt.Errorf("failed to download manifest: %w", err)
+	}
+
+	data, err := os.ReadFile(manifestFile.Name())
+	if err != nil {
+		return fmt.Errorf("failed to read downloaded manifest: %w", err)
+	}
+
+	var manifest FileManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	if len(manifest.Signature) > 0 {
+		hash, err := manifest.signingHash()
+		if err != nil {
+			return err
+		}
+		pubKey, err := crypto.SigToPub(hash.Bytes(), manifest.Signature)
+		if err != nil {
+			return fmt.Errorf("manifest signature verification failed: %w", err)
+		}
+		signer := crypto.PubkeyToAddress(*pubKey)
+
+		if d.config.Blockchain.PrivateKey != "" {
+			expectedKey, err := crypto.HexToECDSA(strings.TrimPrefix(d.config.Blockchain.PrivateKey, "0x"))
+			if err != nil {
+				return fmt.Errorf("failed to parse private key: %w", err)
+			}
+			if expected := crypto.PubkeyToAddress(expectedKey.PublicKey); signer != expected {
+				return fmt.Errorf("manifest signed by untrusted address %s (expected %s)", signer.Hex(), expected.Hex())
+			}
+		}
+
+		d.logger.Infof("Manifest signature verified, signed by: %s", signer.Hex())
+	}
+
+	d.logger.Infof("Downloaded manifest for %q (%d parts, %d bytes)", manifest.Filename, len(manifest.RootHashes), manifest.TotalSize)
+
+	partsDir := filepath.Join(filepath.Dir(outputFile), "downloaded_parts_from_manifest")
+	if err := d.DownloadParts(manifest.RootHashes, partsDir); err != nil {
+		return fmt.Errorf("failed to download parts from manifest: %w", err)
+	}
+
+	if err := d.CombineParts(partsDir, outputFile, len(manifest.RootHashes)); err != nil {
+		return fmt.Errorf("failed to combine parts from manifest: %w", err)
+	}
+
+	return nil
+}
+
 // LoadConfig loads configuration from a JSON file
 func LoadConfig(filename string) (*Config, error) {
 	file, err := os.Open(filename)
@@ -430,37 +1265,75 @@ func main() {
 		fmt.Println()
 	}
 
-	// Step 2: Split file
-	fmt.Println("=" + string(make([]byte, 50)))
-	fmt.Println("STEP 2: Splitting file into parts")
-	fmt.Println("=" + string(make([]byte, 50)))
-	partsDir := filepath.Join(config.File.OutputDirectory, "parts")
-	partFiles, err := demo.SplitFile(config.File.InputFile, partsDir)
-	if err != nil {
-		fmt.Printf("❌ Failed: %v\n", err)
-		os.Exit(1)
-	}
-	fmt.Printf("✓ File split into %d parts successfully\n", len(partFiles))
-	fmt.Println()
+	var partFiles []string
+	var txHashes, rootHashes []ethcommon.Hash
 
-	// Step 3: Upload parts
-	fmt.Println("=" + string(make([]byte, 50)))
-	fmt.Printf("STEP 3: Uploading %d parts\n", len(partFiles))
-	fmt.Println("=" + string(make([]byte, 50)))
-	fmt.Printf("Fragment size: %d bytes (%.2f MB)\n", config.File.FragmentSize, float64(config.File.FragmentSize)/(1024*1024))
-	fmt.Printf("Batch size: %d\n", config.Upload.BatchSize)
-	fmt.Printf("Method: %s\n", config.Upload.Method)
-	fmt.Printf("Expected replicas: %d\n", config.Upload.ExpectedReplica)
-	fmt.Println()
-	txHashes, rootHashes, err := demo.UploadParts(partFiles)
-	if err != nil {
-		fmt.Printf("❌ Failed: %v\n", err)
-		os.Exit(1)
+	if config.File.StreamMode {
+		// Steps 2-3 (stream mode): split and upload in a single in-memory
+		// pass, never materializing part files on disk.
+		fmt.Println("=" + string(make([]byte, 50)))
+		fmt.Println("STEP 2-3: Streaming split-and-upload")
+		fmt.Println("=" + string(make([]byte, 50)))
+		fmt.Printf("Fragment size: %d bytes (%.2f MB)\n", config.File.FragmentSize, float64(config.File.FragmentSize)/(1024*1024))
+		fmt.Printf("Method: %s\n", config.Upload.Method)
+		fmt.Printf("Expected replicas: %d\n", config.Upload.ExpectedReplica)
+		fmt.Println()
+		txHashes, rootHashes, err = demo.StreamUpload(config.File.InputFile)
+		if err != nil {
+			fmt.Printf("❌ Failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println()
+		fmt.Println("✓ All fragments streamed and uploaded successfully")
+		fmt.Printf("Total transactions: %d\n", len(txHashes))
+		fmt.Println()
+	} else {
+		// Step 2: Split file
+		fmt.Println("=" + string(make([]byte, 50)))
+		fmt.Println("STEP 2: Splitting file into parts")
+		fmt.Println("=" + string(make([]byte, 50)))
+		partsDir := filepath.Join(config.File.OutputDirectory, "parts")
+		var manifest *UploadManifest
+		partFiles, manifest, err = demo.SplitFile(config.File.InputFile, partsDir)
+		if err != nil {
+			fmt.Printf("❌ Failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ File split into %d parts successfully\n", len(partFiles))
+		fmt.Println()
+
+		// Flush the upload manifest on SIGINT so a killed demo can resume on
+		// its next run instead of re-uploading every part from scratch.
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt)
+		go func() {
+			<-sigCh
+			fmt.Println("\n⚠ Interrupt received, flushing upload manifest...")
+			if err := manifest.Save(); err != nil {
+				fmt.Printf("❌ Failed to flush upload manifest: %v\n", err)
+			}
+			os.Exit(1)
+		}()
+
+		// Step 3: Upload parts
+		fmt.Println("=" + string(make([]byte, 50)))
+		fmt.Printf("STEP 3: Uploading %d parts\n", len(partFiles))
+		fmt.Println("=" + string(make([]byte, 50)))
+		fmt.Printf("Fragment size: %d bytes (%.2f MB)\n", config.File.FragmentSize, float64(config.File.FragmentSize)/(1024*1024))
+		fmt.Printf("Concurrency: %d\n", config.Upload.Concurrency)
+		fmt.Printf("Method: %s\n", config.Upload.Method)
+		fmt.Printf("Expected replicas: %d\n", config.Upload.ExpectedReplica)
+		fmt.Println()
+		txHashes, rootHashes, err = demo.UploadParts(partFiles, manifest)
+		if err != nil {
+			fmt.Printf("❌ Failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println()
+		fmt.Println("✓ All parts uploaded successfully")
+		fmt.Printf("Total transactions: %d\n", len(txHashes))
+		fmt.Println()
 	}
-	fmt.Println()
-	fmt.Println("✓ All parts uploaded successfully")
-	fmt.Printf("Total transactions: %d\n", len(txHashes))
-	fmt.Println()
 
 	// Step 4: Download parts
 	fmt.Println("=" + string(make([]byte, 50)))
@@ -478,32 +1351,64 @@ func main() {
 	fmt.Println("=" + string(make([]byte, 50)))
 	fmt.Println("STEP 5: Verifying downloaded parts")
 	fmt.Println("=" + string(make([]byte, 50)))
-	if err := demo.VerifyParts(partFiles, downloadDir); err != nil {
-		fmt.Printf("❌ Failed: %v\n", err)
-		os.Exit(1)
+	if config.File.StreamMode {
+		fmt.Println("Skipping local diff (stream mode never materialized original part files)")
+	} else {
+		if err := demo.VerifyParts(partFiles, downloadDir); err != nil {
+			fmt.Printf("❌ Failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("✓ All parts verified successfully")
 	}
 	fmt.Println()
-	fmt.Println("✓ All parts verified successfully")
-	fmt.Println()
 
 	// Step 6: Combine parts
 	fmt.Println("=" + string(make([]byte, 50)))
 	fmt.Println("STEP 6: Combining parts")
 	fmt.Println("=" + string(make([]byte, 50)))
 	finalFile := filepath.Join(config.File.OutputDirectory, "final_file.bin")
-	if err := demo.CombineParts(downloadDir, finalFile); err != nil {
+	if err := demo.CombineParts(downloadDir, finalFile, len(rootHashes)); err != nil {
 		fmt.Printf("❌ Failed: %v\n", err)
 		os.Exit(1)
 	}
 	fmt.Println("✓ Final file created successfully")
 	fmt.Println()
 
+	// Step 7: Publish a root-hash manifest and download the file back by its CID
+	fmt.Println("=" + string(make([]byte, 50)))
+	fmt.Println("STEP 7: Publishing root-hash manifest")
+	fmt.Println("=" + string(make([]byte, 50)))
+	meta := FileMeta{
+		Filename:     filepath.Base(config.File.InputFile),
+		FragmentSize: config.File.FragmentSize,
+	}
+	if info, statErr := os.Stat(finalFile); statErr == nil {
+		meta.TotalSize = info.Size()
+	}
+	manifestRoot, err := demo.PublishManifest(rootHashes, meta)
+	if err != nil {
+		fmt.Printf("❌ Failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Manifest published. CID: %s\n", manifestRoot.Hex())
+	fmt.Println()
+
+	fmt.Println("Downloading file back by manifest CID...")
+	manifestOutputFile := filepath.Join(config.File.OutputDirectory, "final_file_from_manifest.bin")
+	if err := demo.DownloadByManifest(manifestRoot, manifestOutputFile); err != nil {
+		fmt.Printf("❌ Failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Re-downloaded file via manifest CID to: %s\n", manifestOutputFile)
+	fmt.Println()
+
 	// Final summary
 	fmt.Println("=" + string(make([]byte, 50)))
 	fmt.Println("DEMO COMPLETED SUCCESSFULLY!")
 	fmt.Println("=" + string(make([]byte, 50)))
 	fmt.Printf("Final file: %s\n", finalFile)
-	fmt.Printf("Total parts: %d\n", len(partFiles))
+	fmt.Printf("Manifest CID: %s\n", manifestRoot.Hex())
+	fmt.Printf("Total parts: %d\n", len(rootHashes))
 	fmt.Printf("Total transactions: %d\n", len(txHashes))
 	fmt.Println()
 	fmt.Println("Upload summary:")